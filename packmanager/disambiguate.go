@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2022, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package packmanager
+
+import (
+	"context"
+	"fmt"
+
+	"kraftkit.sh/unikraft"
+	"kraftkit.sh/utils"
+)
+
+// PackageManager is implemented by each package manager backend (e.g.
+// manifest, OCI) capable of cataloging and disambiguating Unikraft
+// packages.
+type PackageManager interface {
+	// String returns the name of the package manager, e.g. "manifest" or
+	// "oci".
+	String() string
+
+	// Catalog returns every package known to this manager matching query.
+	Catalog(ctx context.Context, query *Query) ([]Searchable, error)
+
+	// Disambiguate resolves a Query that may match more than one
+	// unikraft.ComponentType to a single MatchCandidate.  When it can't be
+	// resolved automatically, it returns an *AmbiguousMatchError for the
+	// caller (typically the CLI) to prompt with; the caller then narrows the
+	// query with WithTypes (see Query.Narrow) and calls Disambiguate again,
+	// mirroring Go's modget resolver re-running the lookup once the user has
+	// narrowed the type rather than guessing up front.
+	Disambiguate(ctx context.Context, query *Query) (*MatchCandidate, error)
+
+	// Search returns every package matching query's WithSearch term, scored
+	// and ordered per query's WithSort/WithLimit/WithOffset.  Managers with
+	// no native full-text index can implement this with DefaultSearch.
+	Search(ctx context.Context, query *Query) ([]SearchResult, error)
+}
+
+// MatchCandidate is one of the possible resolutions of a Query that did not
+// restrict itself to a single unikraft.ComponentType, e.g. a name like
+// "nginx" that exists as both an application package and a library
+// component.
+type MatchCandidate struct {
+	// Type is the component type this candidate would resolve to.
+	Type unikraft.ComponentType
+
+	// Source is the origin the candidate was found in.
+	Source string
+
+	// Version is the candidate's resolved version.
+	Version string
+}
+
+// AmbiguousMatchError is returned by Resolve when a Query without an
+// explicit WithTypes restriction matches more than one unikraft.ComponentType
+// and none of the caller's WithPreferTypes order breaks the tie.  Candidates
+// lists every match so the caller (typically the CLI) can prompt the user to
+// choose.
+type AmbiguousMatchError struct {
+	Query      *Query
+	Candidates []MatchCandidate
+}
+
+func (e *AmbiguousMatchError) Error() string {
+	var types []string
+	for _, c := range e.Candidates {
+		types = append(types, fmt.Sprintf("%s (%s:%s)", c.Type, c.Source, c.Version))
+	}
+
+	return fmt.Sprintf("%q is ambiguous: matches %s", e.Query.Name(), utils.ListJoinStr(types, ", "))
+}
+
+// Resolve picks a single candidate out of candidates, which are assumed to
+// be every component-type match the catalog found for this query.  It is a
+// pure filter over an already-fetched candidate slice and performs no
+// lookups of its own; re-querying the catalog once the type is narrowed
+// (see Query.Narrow) is the caller's job — DefaultDisambiguate does this
+// for managers that use it.
+//
+// If the query already restricted itself to one type (WithTypes), or only
+// one candidate type was found, that candidate's type is returned
+// unambiguously.  Otherwise, candidates are narrowed by the query's
+// WithPreferTypes order; if that still leaves more than one type, an
+// *AmbiguousMatchError is returned so the caller can re-run the lookup once
+// the user has narrowed the type (see Go's modget resolver, which this
+// mirrors) instead of guessing up front.
+func (query *Query) Resolve(candidates []MatchCandidate) (*MatchCandidate, error) {
+	if len(candidates) == 0 {
+		return nil, ErrPackageNotFound
+	}
+
+	distinctTypes := map[unikraft.ComponentType][]MatchCandidate{}
+	for _, c := range candidates {
+		distinctTypes[c.Type] = append(distinctTypes[c.Type], c)
+	}
+
+	if len(distinctTypes) == 1 {
+		best := candidates[0]
+		return &best, nil
+	}
+
+	if len(query.types) == 1 {
+		if matches, ok := distinctTypes[query.types[0]]; ok {
+			best := matches[0]
+			return &best, nil
+		}
+	}
+
+	for _, preferred := range query.preferTypes {
+		if matches, ok := distinctTypes[preferred]; ok {
+			best := matches[0]
+			return &best, nil
+		}
+	}
+
+	return nil, &AmbiguousMatchError{Query: query, Candidates: candidates}
+}
+
+// Narrow returns a copy of query restricted to the given component types,
+// for re-running a catalog lookup once an *AmbiguousMatchError has been
+// resolved (by user prompt or WithPreferTypes).
+func (query *Query) Narrow(types ...unikraft.ComponentType) *Query {
+	narrowed := *query
+	narrowed.types = types
+	return &narrowed
+}
+
+// DefaultDisambiguate implements PackageManager.Disambiguate for managers
+// with no cheaper way to separate candidates by type: it calls catalog
+// once, groups the results into MatchCandidates by their reported Type, and
+// defers the tiebreak to Query.Resolve.  A caller that gets back an
+// *AmbiguousMatchError should narrow the query with Query.Narrow once the
+// type is known (e.g. from a CLI prompt) and call DefaultDisambiguate
+// again — catalog is re-invoked with the narrowed query, so the second call
+// resolves unambiguously instead of guessing up front.
+func DefaultDisambiguate(ctx context.Context, query *Query, catalog func(context.Context, *Query) ([]Searchable, error)) (*MatchCandidate, error) {
+	results, err := catalog(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]MatchCandidate, 0, len(results))
+	for _, r := range results {
+		candidates = append(candidates, MatchCandidate{
+			Type:    unikraft.ComponentType(r.Type),
+			Source:  r.Source,
+			Version: r.Version,
+		})
+	}
+
+	return query.Resolve(candidates)
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2022, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package packmanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"kraftkit.sh/unikraft"
+)
+
+func TestQueryResolveUnambiguousSingleType(t *testing.T) {
+	q := NewQuery(WithName("nginx"))
+
+	candidates := []MatchCandidate{
+		{Type: unikraft.ComponentType("app"), Source: "index", Version: "1.0.0"},
+		{Type: unikraft.ComponentType("app"), Source: "index", Version: "1.1.0"},
+	}
+
+	got, err := q.Resolve(candidates)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got.Type != unikraft.ComponentType("app") {
+		t.Fatalf("Resolve() = %+v, want type app", got)
+	}
+}
+
+func TestQueryResolveAmbiguous(t *testing.T) {
+	q := NewQuery(WithName("nginx"))
+
+	candidates := []MatchCandidate{
+		{Type: unikraft.ComponentType("app"), Source: "index", Version: "1.0.0"},
+		{Type: unikraft.ComponentType("lib"), Source: "index", Version: "2.0.0"},
+	}
+
+	_, err := q.Resolve(candidates)
+
+	var ambiguous *AmbiguousMatchError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("Resolve() error = %v, want *AmbiguousMatchError", err)
+	}
+	if len(ambiguous.Candidates) != 2 {
+		t.Fatalf("ambiguous.Candidates = %+v, want both candidates listed", ambiguous.Candidates)
+	}
+}
+
+func TestQueryResolvePreferTypesBreaksTie(t *testing.T) {
+	q := NewQuery(WithName("nginx"), WithPreferTypes(unikraft.ComponentType("lib")))
+
+	candidates := []MatchCandidate{
+		{Type: unikraft.ComponentType("app"), Source: "index", Version: "1.0.0"},
+		{Type: unikraft.ComponentType("lib"), Source: "index", Version: "2.0.0"},
+	}
+
+	got, err := q.Resolve(candidates)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if got.Type != unikraft.ComponentType("lib") {
+		t.Fatalf("Resolve() = %+v, want the preferred lib type", got)
+	}
+}
+
+// TestDefaultDisambiguateReResolvesAfterNarrowing exercises the re-query
+// flow the request asked for: an ambiguous first call, followed by the
+// caller narrowing the query with Narrow and calling DefaultDisambiguate
+// again, which re-invokes catalog rather than guessing.
+func TestDefaultDisambiguateReResolvesAfterNarrowing(t *testing.T) {
+	catalogCalls := 0
+	catalog := func(_ context.Context, q *Query) ([]Searchable, error) {
+		catalogCalls++
+
+		results := []Searchable{
+			{Name: "nginx", Type: "app", Source: "index", Version: "1.0.0"},
+			{Name: "nginx", Type: "lib", Source: "index", Version: "2.0.0"},
+		}
+
+		if len(q.Types()) == 0 {
+			return results, nil
+		}
+
+		var filtered []Searchable
+		for _, r := range results {
+			for _, t := range q.Types() {
+				if unikraft.ComponentType(r.Type) == t {
+					filtered = append(filtered, r)
+				}
+			}
+		}
+		return filtered, nil
+	}
+
+	q := NewQuery(WithName("nginx"))
+
+	_, err := DefaultDisambiguate(context.Background(), q, catalog)
+	var ambiguous *AmbiguousMatchError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("first DefaultDisambiguate() error = %v, want *AmbiguousMatchError", err)
+	}
+
+	narrowed := q.Narrow(unikraft.ComponentType("lib"))
+	got, err := DefaultDisambiguate(context.Background(), narrowed, catalog)
+	if err != nil {
+		t.Fatalf("second DefaultDisambiguate() error = %v", err)
+	}
+	if got.Type != unikraft.ComponentType("lib") {
+		t.Fatalf("DefaultDisambiguate() = %+v, want type lib", got)
+	}
+	if catalogCalls != 2 {
+		t.Fatalf("catalog was called %d times, want 2 (one per Disambiguate call)", catalogCalls)
+	}
+}
@@ -0,0 +1,182 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2022, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package packmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrPackageNotFound is returned by a package manager when a query
+	// definitively does not match anything in its catalog.  Dispatch treats
+	// this as a reason to keep trying the remaining sources rather than
+	// aborting the query.
+	ErrPackageNotFound = errors.New("package not found")
+
+	// ErrSourceUnavailable is returned by a package manager when a source
+	// could not be reached or queried (e.g. network failure).  Dispatch treats this the same as ErrPackageNotFound: it
+	// keeps trying the remaining sources.
+	ErrSourceUnavailable = errors.New("source unavailable")
+
+	// ErrAuthRequired is returned by a package manager when a source rejected
+	// the request due to missing or invalid credentials.  Dispatch treats
+	// this as a hard error and aborts immediately, since retrying other
+	// sources is unlikely to resolve an authentication failure against this
+	// one.
+	ErrAuthRequired = errors.New("authentication required")
+)
+
+// sourceError records the outcome of querying a single source.
+type sourceError struct {
+	source string
+	err    error
+}
+
+// QueryError aggregates the per-source errors encountered while dispatching
+// a Query across its sources (see WithSources and Dispatch).  It implements
+// errors.Is/As against ErrPackageNotFound, ErrSourceUnavailable and
+// ErrAuthRequired so that callers can test the aggregate outcome without
+// inspecting every source individually.
+type QueryError struct {
+	errs []sourceError
+}
+
+// Error renders a per-source summary of every failure encountered.
+func (qe *QueryError) Error() string {
+	if len(qe.errs) == 0 {
+		return "no sources were queried"
+	}
+
+	msg := fmt.Sprintf("could not resolve query against %d source(s):", len(qe.errs))
+	for _, se := range qe.errs {
+		msg += fmt.Sprintf("\n  - %s: %s", se.source, se.err)
+	}
+
+	return msg
+}
+
+// Is reports whether target matches the aggregated per-source errors.
+//
+// ErrPackageNotFound is only reported when every source confirmed the
+// package missing: a source that merely came back ErrSourceUnavailable
+// doesn't rule out the package existing there, so the aggregate can't be
+// treated as a confirmed miss just because some other source drew a blank.
+// Every other target (ErrSourceUnavailable, ErrAuthRequired, or anything
+// else a manager returns) is reported as soon as a single source matches,
+// since one inconclusive source already makes the overall outcome
+// inconclusive — which is exactly the "a source is down, try again" signal
+// this classification exists to give the caller.
+func (qe *QueryError) Is(target error) bool {
+	if len(qe.errs) == 0 {
+		return false
+	}
+
+	if target == ErrPackageNotFound {
+		for _, se := range qe.errs {
+			if !errors.Is(se.err, ErrPackageNotFound) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, se := range qe.errs {
+		if errors.Is(se.err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As reports whether any of the aggregated per-source errors matches
+// target's type, populating target with the first match.
+func (qe *QueryError) As(target interface{}) bool {
+	for _, se := range qe.errs {
+		if errors.As(se.err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Unwrap returns the first underlying per-source error that is not
+// ErrPackageNotFound, or the first error when every source missed, primarily
+// so standard library helpers that only look at one level of wrapping still
+// find something useful.  Skipping ErrPackageNotFound keeps errors.Is, which
+// falls through to Unwrap when Is reports false, from reporting a mixed
+// outcome as a confirmed miss.
+func (qe *QueryError) Unwrap() error {
+	for _, se := range qe.errs {
+		if !errors.Is(se.err, ErrPackageNotFound) {
+			return se.err
+		}
+	}
+	if len(qe.errs) == 0 {
+		return nil
+	}
+	return qe.errs[0].err
+}
+
+// Dispatch walks query's sources in order (see WithSources), invoking lookup
+// once per source, and returns the first result that does not come back as
+// ErrPackageNotFound or ErrSourceUnavailable.  Any other error (e.g.
+// ErrAuthRequired, a malformed response, or context cancellation) aborts the
+// walk immediately.  If every source was exhausted without a definitive
+// match, Dispatch returns a *QueryError aggregating each source's error; it
+// reports ErrPackageNotFound only when every source came back with it (see
+// QueryError.Is).
+//
+// Managers that only ever see a single source (the common case) can ignore
+// Dispatch entirely; it exists for callers that want the multi-source
+// fallback behavior of WithSources.
+func Dispatch[T any](ctx context.Context, query *Query, lookup func(ctx context.Context, source string, query *Query) (T, error)) (T, error) {
+	var zero T
+
+	sources := query.Sources()
+	if len(sources) == 0 {
+		sources = []string{""}
+	}
+
+	qe := &QueryError{}
+
+	for _, source := range sources {
+		if err := ctx.Err(); err != nil {
+			return zero, err
+		}
+
+		result, err := lookup(ctx, source, query)
+		if err == nil {
+			return result, nil
+		}
+
+		if errors.Is(err, ErrPackageNotFound) || errors.Is(err, ErrSourceUnavailable) {
+			qe.errs = append(qe.errs, sourceError{source: source, err: err})
+			continue
+		}
+
+		// A hard error (auth failure, cancellation, malformed response) stops
+		// the walk rather than masking it behind the remaining sources.
+		return zero, err
+	}
+
+	if len(qe.errs) == 0 {
+		qe.errs = append(qe.errs, sourceError{source: "", err: ErrPackageNotFound})
+	}
+
+	return zero, qe
+}
+
+// DefaultCatalog resolves a Query across its WithSources chain using
+// Dispatch: it returns the first source's catalog that actually answers the
+// query, which is the "primary registry with a mirror fallback" scenario
+// WithSources exists for — the mirror is only consulted once the primary
+// comes back as ErrPackageNotFound or ErrSourceUnavailable. Package
+// managers backing a single source can call this with a one-element
+// Sources() list (or none at all; Dispatch treats that the same way).
+func DefaultCatalog(ctx context.Context, query *Query, lookup func(ctx context.Context, source string, query *Query) ([]Searchable, error)) ([]Searchable, error) {
+	return Dispatch(ctx, query, lookup)
+}
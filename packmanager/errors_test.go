@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2022, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package packmanager
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestDefaultCatalogFallsBackToMirror(t *testing.T) {
+	query := NewQuery(WithSources("primary", "mirror"))
+
+	got, err := DefaultCatalog(context.Background(), query, func(_ context.Context, source string, _ *Query) ([]Searchable, error) {
+		if source == "primary" {
+			return nil, ErrSourceUnavailable
+		}
+		return []Searchable{{Name: "nginx", Source: source}}, nil
+	})
+	if err != nil {
+		t.Fatalf("DefaultCatalog() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Source != "mirror" {
+		t.Fatalf("DefaultCatalog() = %+v, want a single result from the mirror", got)
+	}
+}
+
+func TestDefaultCatalogAbortsOnHardError(t *testing.T) {
+	query := NewQuery(WithSources("primary", "mirror"))
+
+	calledMirror := false
+	_, err := DefaultCatalog(context.Background(), query, func(_ context.Context, source string, _ *Query) ([]Searchable, error) {
+		if source == "primary" {
+			return nil, ErrAuthRequired
+		}
+		calledMirror = true
+		return nil, nil
+	})
+
+	if !errors.Is(err, ErrAuthRequired) {
+		t.Fatalf("DefaultCatalog() error = %v, want ErrAuthRequired", err)
+	}
+	if calledMirror {
+		t.Fatalf("DefaultCatalog() should not have tried the mirror after a hard error")
+	}
+}
+
+func TestDefaultCatalogAggregatesWhenEverySourceMisses(t *testing.T) {
+	query := NewQuery(WithSources("primary", "mirror"))
+
+	_, err := DefaultCatalog(context.Background(), query, func(_ context.Context, _ string, _ *Query) ([]Searchable, error) {
+		return nil, ErrPackageNotFound
+	})
+
+	var qe *QueryError
+	if !errors.As(err, &qe) {
+		t.Fatalf("DefaultCatalog() error = %v, want *QueryError", err)
+	}
+	if !errors.Is(err, ErrPackageNotFound) {
+		t.Fatalf("errors.Is(err, ErrPackageNotFound) = false, want true")
+	}
+}
+
+// TestQueryErrorIsDoesNotConflateUnavailableWithNotFound reproduces the
+// mixed-outcome case: one source was merely unreachable, the other
+// confirmed the package missing. The aggregate must not be reported as a
+// confirmed ErrPackageNotFound — the unavailable source might still have
+// had it — but it must be reported as ErrSourceUnavailable, since that's
+// the "a source is down, try again" signal the caller needs.
+func TestQueryErrorIsDoesNotConflateUnavailableWithNotFound(t *testing.T) {
+	query := NewQuery(WithSources("primary", "mirror"))
+
+	_, err := DefaultCatalog(context.Background(), query, func(_ context.Context, source string, _ *Query) ([]Searchable, error) {
+		if source == "primary" {
+			return nil, ErrSourceUnavailable
+		}
+		return nil, ErrPackageNotFound
+	})
+
+	if errors.Is(err, ErrPackageNotFound) {
+		t.Fatalf("errors.Is(err, ErrPackageNotFound) = true, want false: primary was only unavailable, not confirmed absent")
+	}
+	if !errors.Is(err, ErrSourceUnavailable) {
+		t.Fatalf("errors.Is(err, ErrSourceUnavailable) = false, want true")
+	}
+}
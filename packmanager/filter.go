@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2022, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package packmanager
+
+import "sort"
+
+// FilterAndSort applies a Query's client-side catalog filters
+// (WithArchitecture, WithPlatform, WithKConfig) and its WithSort/WithLimit/
+// WithOffset options to a list of catalog entries.  Package managers whose
+// backend can already apply some of these filters server-side (e.g. an OCI
+// referrers filter, a manifest index filter) should do so first and only
+// fall back to FilterAndSort for whatever the backend couldn't narrow down
+// itself.
+func FilterAndSort(query *Query, entries []Searchable) []Searchable {
+	filtered := make([]Searchable, 0, len(entries))
+	for _, e := range entries {
+		if matchesFilters(query, e) {
+			filtered = append(filtered, e)
+		}
+	}
+
+	sortSearchables(filtered, query.SortBy())
+
+	return paginate(filtered, query.Limit(), query.Offset())
+}
+
+// paginate applies offset then limit to items, the same order a SQL
+// `OFFSET ... LIMIT ...` clause would.  Shared by FilterAndSort and
+// SearchCatalog, which paginate Searchable and SearchResult respectively.
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset > 0 {
+		if offset >= len(items) {
+			return nil
+		}
+		items = items[offset:]
+	}
+
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+
+	return items
+}
+
+func matchesFilters(query *Query, e Searchable) bool {
+	if arch := query.Architecture(); len(arch) > 0 && e.Architecture != arch {
+		return false
+	}
+
+	if plat := query.Platform(); len(plat) > 0 && e.Platform != plat {
+		return false
+	}
+
+	for k, v := range query.KConfig() {
+		if e.KConfig[k] != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sortSearchables(entries []Searchable, sortBy SortBy) {
+	var less func(a, b Searchable) bool
+
+	switch sortBy {
+	case SortByName:
+		less = func(a, b Searchable) bool { return a.Name < b.Name }
+	case SortByVersion:
+		less = func(a, b Searchable) bool { return compareSemver(a.Version, b.Version) > 0 }
+	case SortBySource:
+		less = func(a, b Searchable) bool { return a.Source < b.Source }
+	default:
+		return
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+}
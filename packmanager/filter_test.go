@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2022, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package packmanager
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterAndSortByArchitectureAndPlatform(t *testing.T) {
+	entries := []Searchable{
+		{Name: "nginx", Architecture: "x86_64", Platform: "qemu"},
+		{Name: "redis", Architecture: "arm64", Platform: "qemu"},
+		{Name: "sqlite", Architecture: "x86_64", Platform: "firecracker"},
+	}
+
+	query := NewQuery(WithArchitecture("x86_64"), WithPlatform("qemu"))
+	got := FilterAndSort(query, entries)
+
+	if len(got) != 1 || got[0].Name != "nginx" {
+		t.Fatalf("FilterAndSort() = %+v, want only nginx", got)
+	}
+}
+
+func TestFilterAndSortByKConfig(t *testing.T) {
+	entries := []Searchable{
+		{Name: "with-ssl", KConfig: map[string]string{"SSL": "y"}},
+		{Name: "without-ssl", KConfig: map[string]string{"SSL": "n"}},
+	}
+
+	query := NewQuery(WithKConfig(map[string]string{"SSL": "y"}))
+	got := FilterAndSort(query, entries)
+
+	if len(got) != 1 || got[0].Name != "with-ssl" {
+		t.Fatalf("FilterAndSort() = %+v, want only with-ssl", got)
+	}
+}
+
+func TestFilterAndSortOrdersByName(t *testing.T) {
+	entries := []Searchable{{Name: "zebra"}, {Name: "alpha"}, {Name: "mid"}}
+
+	query := NewQuery(WithSort(SortByName))
+	got := FilterAndSort(query, entries)
+
+	var names []string
+	for _, e := range got {
+		names = append(names, e.Name)
+	}
+
+	want := []string{"alpha", "mid", "zebra"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("order = %v, want %v", names, want)
+	}
+}
+
+func TestFilterAndSortPaginates(t *testing.T) {
+	entries := []Searchable{
+		{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"},
+	}
+
+	query := NewQuery(WithSort(SortByName), WithOffset(2), WithLimit(2))
+	got := FilterAndSort(query, entries)
+
+	var names []string
+	for _, e := range got {
+		names = append(names, e.Name)
+	}
+
+	want := []string{"c", "d"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("page = %v, want %v", names, want)
+	}
+}
+
+func TestFilterAndSortOffsetPastEndReturnsEmpty(t *testing.T) {
+	entries := []Searchable{{Name: "a"}, {Name: "b"}}
+
+	query := NewQuery(WithOffset(10))
+	got := FilterAndSort(query, entries)
+
+	if len(got) != 0 {
+		t.Fatalf("FilterAndSort() = %+v, want empty", got)
+	}
+}
@@ -13,8 +13,11 @@ import (
 // Query is the request structure with associated attributes which are used to
 // search the package manager's catalog
 type Query struct {
-	// Source specifies where the origin of the package
-	source string
+	// sources specifies the ordered list of origins to try for the package.
+	// A query with more than one source is tried in order, falling back to
+	// the next source only when the previous one could not definitively
+	// answer the query (see Dispatch).
+	sources []string
 
 	// Types specifies the associated list of possible types for the package
 	types []unikraft.ComponentType
@@ -25,6 +28,10 @@ type Query struct {
 	// Version specifies the version of the package
 	version string
 
+	// versionExpr is the parsed form of version, used by package managers to
+	// resolve expressions such as "latest", "patch" or ">=1.2,<2".
+	versionExpr *VersionQuery
+
 	// useCache forces the package manager to update values using what it has
 	// locally.
 	useCache bool
@@ -43,11 +50,74 @@ type Query struct {
 	// If set to true then no OCI package will be deleted
 	// (Currently, being used to prune all the packages on the host machine)
 	noOCIPackage bool
+
+	// architecture narrows the query to packages which support the given
+	// target architecture.
+	architecture string
+
+	// platform narrows the query to packages which support the given target
+	// platform.
+	platform string
+
+	// kconfig narrows the query to packages which provide the given KConfig
+	// values.
+	kconfig map[string]string
+
+	// sortBy specifies the order in which catalog results should be returned.
+	sortBy SortBy
+
+	// limit caps the number of results returned by the query.  Zero means no
+	// limit.
+	limit int
+
+	// offset skips the given number of results before the first one returned,
+	// for use alongside limit to paginate.
+	offset int
+
+	// preferTypes is the tiebreak order used by Resolve when a query without
+	// an explicit Types restriction matches more than one component type.
+	preferTypes []unikraft.ComponentType
+
+	// search is a free-text term to match against package metadata, for use
+	// with Search.
+	search string
+
+	// searchFields restricts which metadata fields search is matched
+	// against.  Empty means every field in searchableFields.
+	searchFields []SearchField
 }
 
-// Source specifies where the origin of the package
+// SortBy specifies the field catalog results should be ordered by.
+type SortBy int
+
+const (
+	// SortByUnsorted leaves results in the order the package manager
+	// returned them.
+	SortByUnsorted SortBy = iota
+
+	// SortByName orders results alphabetically by package name.
+	SortByName
+
+	// SortByVersion orders results by version, newest first.
+	SortByVersion
+
+	// SortBySource orders results by their origin source.
+	SortBySource
+)
+
+// Source specifies where the origin of the package.  When the query carries
+// more than one source (see WithSources), this returns the first one; use
+// Sources to retrieve the whole fallback chain.
 func (query *Query) Source() string {
-	return query.source
+	if len(query.sources) == 0 {
+		return ""
+	}
+	return query.sources[0]
+}
+
+// Sources specifies the ordered list of origins to try for the package.
+func (query *Query) Sources() []string {
+	return query.sources
 }
 
 // Types specifies the associated list of possible types for the package
@@ -65,6 +135,13 @@ func (query *Query) Version() string {
 	return query.version
 }
 
+// VersionExpr returns the parsed form of Version(), which package managers
+// should use to resolve expressions such as "latest", "patch" or ranges
+// rather than comparing Version() verbatim.
+func (query *Query) VersionExpr() *VersionQuery {
+	return query.versionExpr
+}
+
 // UseCache indicates whether the package manager should use any existing cache.
 func (query *Query) UseCache() bool {
 	return query.useCache
@@ -89,15 +166,92 @@ func (query *Query) NoOCIPackage() bool {
 	return query.noOCIPackage
 }
 
+// Architecture specifies the target architecture to filter the query by.
+func (query *Query) Architecture() string {
+	return query.architecture
+}
+
+// Platform specifies the target platform to filter the query by.
+func (query *Query) Platform() string {
+	return query.platform
+}
+
+// KConfig specifies the KConfig values to filter the query by.
+func (query *Query) KConfig() map[string]string {
+	return query.kconfig
+}
+
+// SortBy specifies the field catalog results should be ordered by.
+func (query *Query) SortBy() SortBy {
+	return query.sortBy
+}
+
+// Limit caps the number of results returned by the query.  Zero means no
+// limit.
+func (query *Query) Limit() int {
+	return query.limit
+}
+
+// Offset skips the given number of results before the first one returned.
+func (query *Query) Offset() int {
+	return query.offset
+}
+
+// PreferTypes specifies the tiebreak order used by Resolve when a query
+// without an explicit Types restriction matches more than one component
+// type.
+func (query *Query) PreferTypes() []unikraft.ComponentType {
+	return query.preferTypes
+}
+
+// Search is the free-text term to match against package metadata.
+func (query *Query) Search() string {
+	return query.search
+}
+
+// SearchFields restricts which metadata fields Search is matched against.
+// An empty slice means every field in searchableFields.
+func (query *Query) SearchFields() []SearchField {
+	return query.searchFields
+}
+
 func (query *Query) Fields() map[string]interface{} {
-	return map[string]interface{}{
+	fields := map[string]interface{}{
 		"name":    query.name,
 		"version": query.version,
-		"source":  query.source,
+		"source":  query.sources,
 		"types":   query.types,
 		"cache":   query.useCache,
 		"auth":    query.auths != nil,
 	}
+
+	if query.versionExpr != nil {
+		fields["versionKind"] = query.versionExpr.Kind
+	}
+
+	if len(query.architecture) > 0 {
+		fields["architecture"] = query.architecture
+	}
+	if len(query.platform) > 0 {
+		fields["platform"] = query.platform
+	}
+	if len(query.kconfig) > 0 {
+		fields["kconfig"] = query.kconfig
+	}
+	if query.sortBy != SortByUnsorted {
+		fields["sortBy"] = query.sortBy
+	}
+	if query.limit > 0 {
+		fields["limit"] = query.limit
+	}
+	if query.offset > 0 {
+		fields["offset"] = query.offset
+	}
+	if len(query.search) > 0 {
+		fields["search"] = query.search
+	}
+
+	return fields
 }
 
 // QueryOption is a method-option which sets a specific query parameter.
@@ -113,9 +267,18 @@ func NewQuery(qopts ...QueryOption) *Query {
 }
 
 // WithSource sets the query parameter for the origin source of the package.
+// It is a single-element shortcut for WithSources.
 func WithSource(source string) QueryOption {
+	return WithSources(source)
+}
+
+// WithSources sets the ordered list of origins to try for the package.  The
+// package manager dispatcher (see Dispatch) walks the list in order,
+// falling back to the next source only when the previous one could not
+// definitively answer the query.
+func WithSources(sources ...string) QueryOption {
 	return func(query *Query) {
-		query.source = source
+		query.sources = sources
 	}
 }
 
@@ -134,10 +297,14 @@ func WithName(name string) QueryOption {
 	}
 }
 
-// WithVersion sets the query parameter for the version of the package.
+// WithVersion sets the query parameter for the version of the package.  The
+// version string is additionally parsed into a VersionQuery (see
+// VersionExpr) so that managers can resolve expressions such as "latest",
+// "upgrade", "patch" or version ranges instead of matching it verbatim.
 func WithVersion(version string) QueryOption {
 	return func(query *Query) {
 		query.version = version
+		query.versionExpr = ParseVersionQuery(version)
 	}
 }
 
@@ -173,6 +340,78 @@ func WithNoOCIPackage(noOCIPackage bool) QueryOption {
 	}
 }
 
+// WithArchitecture sets the query parameter for the target architecture to
+// search for.
+func WithArchitecture(arch string) QueryOption {
+	return func(query *Query) {
+		query.architecture = arch
+	}
+}
+
+// WithPlatform sets the query parameter for the target platform to search
+// for.
+func WithPlatform(plat string) QueryOption {
+	return func(query *Query) {
+		query.platform = plat
+	}
+}
+
+// WithKConfig sets the query parameter for the KConfig values to search for.
+func WithKConfig(kconfig map[string]string) QueryOption {
+	return func(query *Query) {
+		query.kconfig = kconfig
+	}
+}
+
+// WithSort sets the field catalog results should be ordered by.
+func WithSort(sortBy SortBy) QueryOption {
+	return func(query *Query) {
+		query.sortBy = sortBy
+	}
+}
+
+// WithLimit caps the number of results returned by the query.
+func WithLimit(limit int) QueryOption {
+	return func(query *Query) {
+		query.limit = limit
+	}
+}
+
+// WithOffset skips the given number of results before the first one
+// returned, for use alongside WithLimit to paginate.
+func WithOffset(offset int) QueryOption {
+	return func(query *Query) {
+		query.offset = offset
+	}
+}
+
+// WithPreferTypes sets the tiebreak order Resolve uses when a query without
+// an explicit WithTypes restriction matches more than one component type.
+// Types not listed are left for the caller (or CLI) to disambiguate
+// interactively.
+func WithPreferTypes(order ...unikraft.ComponentType) QueryOption {
+	return func(query *Query) {
+		query.preferTypes = order
+	}
+}
+
+// WithSearch sets a free-text term to match against package metadata (name,
+// description, provided features, keywords and maintainer).  Use
+// WithSearchFields to restrict which of those fields are considered.
+func WithSearch(term string) QueryOption {
+	return func(query *Query) {
+		query.search = term
+	}
+}
+
+// WithSearchFields restricts which metadata fields WithSearch is matched
+// against.  Omitting this searches every field in searchableFields.
+func WithSearchFields(fields ...SearchField) QueryOption {
+	return func(query *Query) {
+		query.searchFields = fields
+	}
+}
+
 func (cq Query) String() string {
 	s := ""
 	if len(cq.types) == 1 {
@@ -196,5 +435,15 @@ func (cq Query) String() string {
 		s += ":" + cq.version
 	}
 
+	if len(cq.architecture) > 0 {
+		s += " (" + cq.architecture
+		if len(cq.platform) > 0 {
+			s += "/" + cq.platform
+		}
+		s += ")"
+	} else if len(cq.platform) > 0 {
+		s += " (" + cq.platform + ")"
+	}
+
 	return s
 }
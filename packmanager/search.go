@@ -0,0 +1,230 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2022, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package packmanager
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// SearchField is a package metadata field that WithSearch can be matched
+// against.
+type SearchField int
+
+const (
+	// SearchFieldName matches against the package name.
+	SearchFieldName SearchField = iota
+
+	// SearchFieldDescription matches against the package description.
+	SearchFieldDescription
+
+	// SearchFieldProvides matches against the features/packages the package
+	// provides.
+	SearchFieldProvides
+
+	// SearchFieldKeywords matches against the package's keyword list.
+	SearchFieldKeywords
+
+	// SearchFieldMaintainer matches against the package maintainer.
+	SearchFieldMaintainer
+)
+
+// searchableFields is the default set of fields searched when a query does
+// not restrict itself via WithSearchFields.
+var searchableFields = []SearchField{
+	SearchFieldName,
+	SearchFieldDescription,
+	SearchFieldProvides,
+	SearchFieldKeywords,
+	SearchFieldMaintainer,
+}
+
+// Searchable is the metadata a package manager exposes for full-text search.
+// Package managers with no native search (e.g. no server-side text index)
+// can satisfy this from whatever struct their Catalog already returns.
+type Searchable struct {
+	Name        string
+	Source      string
+	Version     string
+	Type        string
+	Description string
+	Provides    []string
+	Keywords    []string
+	Maintainer  string
+
+	// Architecture and Platform are the target(s) this package supports, and
+	// KConfig is the feature set it was built with.  These back the
+	// WithArchitecture/WithPlatform/WithKConfig query filters (see
+	// FilterAndSort) for managers with no server-side equivalent.
+	Architecture string
+	Platform     string
+	KConfig      map[string]string
+}
+
+// SearchResult is a single match produced by Search, carrying enough to
+// both display the package and explain why it matched.
+type SearchResult struct {
+	// Name, Source, Version and Type identify the matched package.
+	Name    string
+	Source  string
+	Version string
+	Type    string
+
+	// Score is a simple TF-like relevance weighting: an exact name match
+	// scores highest, then a name prefix match, then a description token
+	// match, then a keyword hit.
+	Score float64
+
+	// MatchedField is the SearchField the highest-scoring match was found
+	// in.
+	MatchedField SearchField
+}
+
+// Score weights, applied in descending order of specificity.
+const (
+	scoreExactName        = 100.0
+	scoreNamePrefix       = 75.0
+	scoreDescriptionToken = 40.0
+	scoreMaintainerHit    = 30.0
+	scoreProvidesHit      = 25.0
+	scoreKeywordHit       = 10.0
+)
+
+// ScoreSearchable scores a single Searchable against term across fields,
+// returning the highest-scoring field match and its score.  A score of zero
+// means term did not match any considered field.
+func ScoreSearchable(term string, fields []SearchField, s Searchable) (score float64, field SearchField) {
+	if len(fields) == 0 {
+		fields = searchableFields
+	}
+
+	term = strings.ToLower(strings.TrimSpace(term))
+	if term == "" {
+		return 0, SearchFieldName
+	}
+
+	for _, f := range fields {
+		var candidateScore float64
+
+		switch f {
+		case SearchFieldName:
+			name := strings.ToLower(s.Name)
+			switch {
+			case name == term:
+				candidateScore = scoreExactName
+			case strings.HasPrefix(name, term):
+				candidateScore = scoreNamePrefix
+			case strings.Contains(name, term):
+				candidateScore = scoreDescriptionToken
+			}
+
+		case SearchFieldDescription:
+			if containsToken(s.Description, term) {
+				candidateScore = scoreDescriptionToken
+			}
+
+		case SearchFieldProvides:
+			if containsAny(s.Provides, term) {
+				candidateScore = scoreProvidesHit
+			}
+
+		case SearchFieldKeywords:
+			if containsAny(s.Keywords, term) {
+				candidateScore = scoreKeywordHit
+			}
+
+		case SearchFieldMaintainer:
+			if containsToken(s.Maintainer, term) {
+				candidateScore = scoreMaintainerHit
+			}
+		}
+
+		if candidateScore > score {
+			score = candidateScore
+			field = f
+		}
+	}
+
+	return score, field
+}
+
+func containsToken(haystack, term string) bool {
+	return strings.Contains(strings.ToLower(haystack), term)
+}
+
+func containsAny(haystack []string, term string) bool {
+	for _, h := range haystack {
+		if strings.Contains(strings.ToLower(h), term) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultSearch implements PackageManager.Search for managers with no
+// native full-text index: it fetches catalog once via the given function
+// and scores the result in memory with SearchCatalog.
+func DefaultSearch(ctx context.Context, query *Query, catalog func(context.Context, *Query) ([]Searchable, error)) ([]SearchResult, error) {
+	entries, err := catalog(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	return SearchCatalog(query, entries), nil
+}
+
+// SearchCatalog is the in-memory scoring half of DefaultSearch: it applies
+// query's architecture/platform/kconfig filters (see FilterAndSort), scores
+// what's left against query's search term and fields, drops non-matches,
+// and orders and paginates the rest per query's WithSort/WithLimit/
+// WithOffset (falling back to descending score when unsorted).
+func SearchCatalog(query *Query, catalog []Searchable) []SearchResult {
+	var results []SearchResult
+
+	fields := query.SearchFields()
+	term := query.Search()
+
+	for _, s := range catalog {
+		if !matchesFilters(query, s) {
+			continue
+		}
+
+		score, field := ScoreSearchable(term, fields, s)
+		if score <= 0 {
+			continue
+		}
+
+		results = append(results, SearchResult{
+			Name:         s.Name,
+			Source:       s.Source,
+			Version:      s.Version,
+			Type:         s.Type,
+			Score:        score,
+			MatchedField: field,
+		})
+	}
+
+	sortSearchResults(results, query.SortBy())
+
+	return paginate(results, query.Limit(), query.Offset())
+}
+
+func sortSearchResults(results []SearchResult, sortBy SortBy) {
+	var less func(a, b SearchResult) bool
+
+	switch sortBy {
+	case SortByName:
+		less = func(a, b SearchResult) bool { return a.Name < b.Name }
+	case SortByVersion:
+		less = func(a, b SearchResult) bool { return compareSemver(a.Version, b.Version) > 0 }
+	case SortBySource:
+		less = func(a, b SearchResult) bool { return a.Source < b.Source }
+	default:
+		less = func(a, b SearchResult) bool { return a.Score > b.Score }
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return less(results[i], results[j]) })
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2022, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package packmanager
+
+import (
+	"context"
+	"testing"
+)
+
+func TestScoreSearchableRanksExactNameHighest(t *testing.T) {
+	exact, _ := ScoreSearchable("nginx", nil, Searchable{Name: "nginx"})
+	prefix, _ := ScoreSearchable("nginx", nil, Searchable{Name: "nginx-unit"})
+	description, _ := ScoreSearchable("nginx", nil, Searchable{Name: "proxy", Description: "a reverse proxy like nginx"})
+
+	if !(exact > prefix && prefix > description) {
+		t.Fatalf("expected exact > prefix > description, got exact=%v prefix=%v description=%v", exact, prefix, description)
+	}
+}
+
+func TestSearchCatalogDropsNonMatches(t *testing.T) {
+	catalog := []Searchable{{Name: "nginx"}, {Name: "redis"}}
+
+	q := NewQuery(WithSearch("nginx"))
+	got := SearchCatalog(q, catalog)
+
+	if len(got) != 1 || got[0].Name != "nginx" {
+		t.Fatalf("SearchCatalog() = %+v, want only nginx", got)
+	}
+}
+
+func TestSearchCatalogAppliesArchitectureFilter(t *testing.T) {
+	catalog := []Searchable{
+		{Name: "nginx", Architecture: "x86_64"},
+		{Name: "nginx-arm", Architecture: "arm64"},
+	}
+
+	q := NewQuery(WithSearch("nginx"), WithArchitecture("arm64"))
+	got := SearchCatalog(q, catalog)
+
+	if len(got) != 1 || got[0].Name != "nginx-arm" {
+		t.Fatalf("SearchCatalog() = %+v, want only nginx-arm", got)
+	}
+}
+
+func TestSearchCatalogPaginatesWithOffsetAndLimit(t *testing.T) {
+	catalog := []Searchable{
+		{Name: "nginx-a"}, {Name: "nginx-b"}, {Name: "nginx-c"}, {Name: "nginx-d"},
+	}
+
+	q := NewQuery(WithSearch("nginx"), WithSort(SortByName), WithOffset(1), WithLimit(2))
+	got := SearchCatalog(q, catalog)
+
+	if len(got) != 2 {
+		t.Fatalf("SearchCatalog() returned %d results, want 2", len(got))
+	}
+	if got[0].Name != "nginx-b" || got[1].Name != "nginx-c" {
+		t.Fatalf("SearchCatalog() = %+v, want [nginx-b nginx-c] (offset applied before limit)", got)
+	}
+}
+
+func TestDefaultSearchCallsCatalogAndScores(t *testing.T) {
+	q := NewQuery(WithSearch("nginx"))
+
+	got, err := DefaultSearch(context.Background(), q, func(_ context.Context, _ *Query) ([]Searchable, error) {
+		return []Searchable{{Name: "nginx"}, {Name: "redis"}}, nil
+	})
+	if err != nil {
+		t.Fatalf("DefaultSearch() error = %v", err)
+	}
+	if len(got) != 1 || got[0].Name != "nginx" {
+		t.Fatalf("DefaultSearch() = %+v, want only nginx", got)
+	}
+}
@@ -0,0 +1,467 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2022, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package packmanager
+
+import (
+	"strconv"
+	"strings"
+)
+
+// VersionKind classifies how a version expression passed to WithVersion
+// should be resolved against a package's available versions.
+type VersionKind int
+
+const (
+	// VersionKindAny means no version was specified at all — WithVersion was
+	// never called, or was called with an empty string.  Every candidate
+	// matches, including prereleases; callers that want the "latest
+	// non-prerelease tag" behavior should use VersionKindLatest instead.
+	VersionKindAny VersionKind = iota
+
+	// VersionKindExact means the query names a specific, literal version (or
+	// tag) which must match candidates byte-for-byte.
+	VersionKindExact
+
+	// VersionKindLatest resolves to the newest non-prerelease tagged version,
+	// falling back to the newest commit when no tags are available.
+	VersionKindLatest
+
+	// VersionKindUpgrade resolves to the latest version unless the currently
+	// selected version is already newer.
+	VersionKindUpgrade
+
+	// VersionKindPatch resolves to the latest tag sharing the currently
+	// selected version's major.minor series.
+	VersionKindPatch
+
+	// VersionKindPrefix resolves to the latest version matching a bare "vX" or
+	// "vX.Y" prefix.
+	VersionKindPrefix
+
+	// VersionKindRange resolves to the latest version satisfying one or more
+	// comma-joined comparison constraints, e.g. ">=1.2.3,<2.0.0".
+	VersionKindRange
+)
+
+// versionConstraint is a single comparison operand within a (possibly
+// comma-joined) VersionKindRange expression.
+type versionConstraint struct {
+	operator string
+	semver   string
+}
+
+// VersionQuery is the parsed form of the string passed to WithVersion.  It is
+// modeled on the grammar used by Go's module resolver (`go get`) so that the
+// manifest and OCI package managers can share a single matcher rather than
+// each reimplementing ad-hoc string comparisons.
+type VersionQuery struct {
+	// Kind is the category of version expression that was parsed.
+	Kind VersionKind
+
+	// Operator is the comparison operator of a VersionKindRange expression
+	// (">=", "<=", ">", "<", "=="), and empty for every other kind.  When the
+	// expression is a comma-joined intersection, Operator and Semver describe
+	// only the first constraint; Match evaluates every constraint.
+	Operator string
+
+	// Semver is the literal version string for VersionKindExact and the first
+	// constraint of a VersionKindRange.
+	Semver string
+
+	// Prefix is the bare "vX" or "vX.Y" prefix for VersionKindPrefix.
+	Prefix string
+
+	// raw is the original, unparsed expression, preserved so that Version()
+	// keeps returning exactly what the caller passed in.
+	raw string
+
+	// constraints holds every operand of a comma-joined VersionKindRange
+	// expression, including the first (which is mirrored in
+	// Operator/Semver).
+	constraints []versionConstraint
+}
+
+// comparisonOperators is ordered so that two-character operators are matched
+// before their single-character prefixes (">=" before ">").
+var comparisonOperators = []string{">=", "<=", "==", ">", "<"}
+
+// ParseVersionQuery parses a version expression using the grammar documented
+// on VersionQuery.  An empty expression (no WithVersion call) parses to
+// VersionKindAny, matching every candidate.  Any other expression that
+// cannot be parsed falls back to VersionKindExact so that existing
+// exact-match callers keep working.
+func ParseVersionQuery(expr string) *VersionQuery {
+	vq := &VersionQuery{raw: expr}
+
+	trimmed := strings.TrimSpace(expr)
+	switch trimmed {
+	case "":
+		vq.Kind = VersionKindAny
+		return vq
+	case "latest":
+		vq.Kind = VersionKindLatest
+		return vq
+	case "upgrade":
+		vq.Kind = VersionKindUpgrade
+		return vq
+	case "patch":
+		vq.Kind = VersionKindPatch
+		return vq
+	}
+
+	if strings.Contains(trimmed, ",") || hasComparisonPrefix(trimmed) {
+		if constraints, ok := parseRange(trimmed); ok {
+			vq.Kind = VersionKindRange
+			vq.Operator = constraints[0].operator
+			vq.Semver = constraints[0].semver
+			vq.constraints = constraints
+			return vq
+		}
+		// Falls through to the exact-match fallback below.
+	} else if prefix, ok := versionPrefix(trimmed); ok {
+		vq.Kind = VersionKindPrefix
+		vq.Prefix = prefix
+		return vq
+	}
+
+	vq.Kind = VersionKindExact
+	vq.Semver = trimmed
+	return vq
+}
+
+func parseRange(expr string) ([]versionConstraint, bool) {
+	parts := strings.Split(expr, ",")
+	constraints := make([]versionConstraint, 0, len(parts))
+
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		operator, version := splitComparisonPrefix(part)
+		if version == "" {
+			return nil, false
+		}
+		constraints = append(constraints, versionConstraint{operator: operator, semver: version})
+	}
+
+	return constraints, true
+}
+
+func hasComparisonPrefix(s string) bool {
+	_, version := splitComparisonPrefix(s)
+	return version != "" && s != version
+}
+
+// splitComparisonPrefix splits a range operand such as ">=1.2.3" into its
+// operator and version.  A bare version (no operator) within a comma-joined
+// list is treated as an exact match.
+func splitComparisonPrefix(s string) (operator, version string) {
+	for _, op := range comparisonOperators {
+		if strings.HasPrefix(s, op) {
+			return op, strings.TrimSpace(strings.TrimPrefix(s, op))
+		}
+	}
+
+	return "==", s
+}
+
+// versionPrefix reports whether expr is a bare "vX" or "vX.Y" prefix (no
+// patch component and no pre-release/build metadata), returning the
+// normalized prefix on success.
+func versionPrefix(expr string) (string, bool) {
+	if len(expr) < 2 || expr[0] != 'v' {
+		return "", false
+	}
+
+	segments := strings.Split(expr[1:], ".")
+	if len(segments) > 2 {
+		return "", false
+	}
+
+	for _, seg := range segments {
+		if seg == "" {
+			return "", false
+		}
+		if _, err := strconv.Atoi(seg); err != nil {
+			return "", false
+		}
+	}
+
+	return expr, true
+}
+
+// Match reports whether candidate satisfies this version expression given
+// the currently selected version, which may be empty when there is none.
+func (vq *VersionQuery) Match(candidate, current string) bool {
+	if vq.Kind != VersionKindAny && isPrerelease(candidate) && !vq.wantsPrerelease() {
+		return false
+	}
+
+	switch vq.Kind {
+	case VersionKindAny:
+		return true
+
+	case VersionKindExact:
+		return candidate == vq.Semver
+
+	case VersionKindLatest:
+		return isPrerelease(candidate) == vq.wantsPrerelease()
+
+	case VersionKindUpgrade:
+		if current == "" {
+			return isPrerelease(candidate) == vq.wantsPrerelease()
+		}
+		return compareSemver(candidate, current) >= 0
+
+	case VersionKindPatch:
+		if current == "" {
+			return isPrerelease(candidate) == vq.wantsPrerelease()
+		}
+		return sameMajorMinor(candidate, current)
+
+	case VersionKindPrefix:
+		return hasVersionPrefix(candidate, vq.Prefix)
+
+	case VersionKindRange:
+		for _, c := range vq.constraints {
+			if !matchConstraint(candidate, c) {
+				return false
+			}
+		}
+		return true
+
+	default:
+		return candidate == vq.raw
+	}
+}
+
+// SelectBest filters candidates down to those that satisfy Match, and
+// returns the single best one, or "" if none match.
+//
+// Candidates that parse as semver are ordered with semver precedence.
+// Candidates that don't (branch names, commit hashes) carry no
+// chronological signal in the string itself, so they are assumed to be
+// supplied oldest-first, as a manifest's commit listing or an OCI tag list
+// would return them; the last such candidate is treated as the newest. A
+// semver-tagged candidate always outranks an untagged one, matching the
+// "latest tag, falling back to newest commit" rule VersionKindLatest
+// documents.
+func (vq *VersionQuery) SelectBest(candidates []string, current string) string {
+	var best string
+	var bestIsSemver, haveBest bool
+
+	for _, c := range candidates {
+		if !vq.Match(c, current) {
+			continue
+		}
+
+		cIsSemver := isSemver(c)
+
+		switch {
+		case !haveBest:
+			best, bestIsSemver, haveBest = c, cIsSemver, true
+		case cIsSemver && bestIsSemver:
+			if compareSemver(c, best) > 0 {
+				best = c
+			}
+		case cIsSemver && !bestIsSemver:
+			best, bestIsSemver = c, true
+		case !cIsSemver && !bestIsSemver:
+			best = c
+		}
+		// cIsSemver == false && bestIsSemver == true: a real tag always
+		// outranks an opaque candidate, so best is left untouched.
+	}
+
+	return best
+}
+
+// ResolveVersion filters candidates with SelectBest and reports
+// ErrPackageNotFound when none of them satisfy this expression.
+func (vq *VersionQuery) ResolveVersion(candidates []string, current string) (string, error) {
+	best := vq.SelectBest(candidates, current)
+	if best == "" {
+		return "", ErrPackageNotFound
+	}
+
+	return best, nil
+}
+
+// ResolveVersion narrows candidates down to the single version satisfying
+// query's version expression (see WithVersion and VersionExpr), returning
+// ErrPackageNotFound if none match. current is the currently selected
+// version, if any, used by the "upgrade" and "patch" expressions.
+func (query *Query) ResolveVersion(candidates []string, current string) (string, error) {
+	expr := query.versionExpr
+	if expr == nil {
+		expr = ParseVersionQuery(query.version)
+	}
+
+	return expr.ResolveVersion(candidates, current)
+}
+
+// wantsPrerelease reports whether the original expression itself contains a
+// pre-release segment (in its exact version or any range operand), in which
+// case prerelease candidates are allowed to match; otherwise they are
+// excluded.
+func (vq *VersionQuery) wantsPrerelease() bool {
+	if isPrerelease(vq.Semver) || isPrerelease(strings.TrimSpace(vq.raw)) {
+		return true
+	}
+	for _, c := range vq.constraints {
+		if isPrerelease(c.semver) {
+			return true
+		}
+	}
+	return false
+}
+
+// semver is a minimal major.minor.patch[-prerelease] decomposition, just
+// enough to order and compare the tags Unikraft packages use.
+type semver struct {
+	major, minor, patch int
+	prerelease          string
+}
+
+func parseSemver(v string) (semver, bool) {
+	v = strings.TrimPrefix(v, "v")
+
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+
+	var pre string
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		v, pre = v[:i], v[i+1:]
+	}
+
+	parts := strings.SplitN(v, ".", 3)
+	var nums [3]int
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return semver{}, false
+		}
+		nums[i] = n
+	}
+
+	return semver{major: nums[0], minor: nums[1], patch: nums[2], prerelease: pre}, true
+}
+
+// compareSemver orders a and b using semver precedence, returning -1, 0 or 1.
+// Values that fail to parse (e.g. branch names or commit hashes) carry no
+// chronological signal on their own and compare equal; use SelectBest when
+// choosing among candidates that may not be tagged versions, since it falls
+// back to the order candidates were supplied in rather than guessing from
+// the string contents.
+func compareSemver(a, b string) int {
+	sa, oka := parseSemver(a)
+	sb, okb := parseSemver(b)
+	if !oka || !okb {
+		return 0
+	}
+
+	return compareParsedSemver(sa, sb)
+}
+
+// CompareVersions is the exported form of compareSemver, for package
+// managers that need to order their own version lists (e.g. to pick the
+// newest tag out of several matches) without duplicating semver precedence
+// rules. See compareSemver for how unparseable values are handled.
+func CompareVersions(a, b string) int {
+	return compareSemver(a, b)
+}
+
+func compareParsedSemver(sa, sb semver) int {
+	if d := sa.major - sb.major; d != 0 {
+		return sign(d)
+	}
+	if d := sa.minor - sb.minor; d != 0 {
+		return sign(d)
+	}
+	if d := sa.patch - sb.patch; d != 0 {
+		return sign(d)
+	}
+
+	switch {
+	case sa.prerelease == sb.prerelease:
+		return 0
+	case sa.prerelease == "":
+		return 1
+	case sb.prerelease == "":
+		return -1
+	default:
+		return strings.Compare(sa.prerelease, sb.prerelease)
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func isPrerelease(v string) bool {
+	s, ok := parseSemver(v)
+	return ok && s.prerelease != ""
+}
+
+func isSemver(v string) bool {
+	_, ok := parseSemver(v)
+	return ok
+}
+
+func sameMajorMinor(candidate, current string) bool {
+	sc, okc := parseSemver(candidate)
+	sv, okv := parseSemver(current)
+	return okc && okv && sc.major == sv.major && sc.minor == sv.minor
+}
+
+func hasVersionPrefix(candidate, prefix string) bool {
+	sc, ok := parseSemver(candidate)
+	sp, okp := parseSemver(prefix)
+	if !ok || !okp {
+		return strings.HasPrefix(candidate, prefix)
+	}
+
+	if sc.major != sp.major {
+		return false
+	}
+
+	// A bare "vX" prefix constrains only the major version; "vX.Y" also
+	// constrains the minor version.
+	if !strings.Contains(strings.TrimPrefix(prefix, "v"), ".") {
+		return true
+	}
+
+	return sc.minor == sp.minor
+}
+
+func matchConstraint(candidate string, c versionConstraint) bool {
+	// A range constraint only has meaning against an actual semantic
+	// version; an opaque branch name or commit hash never satisfies one.
+	if !isSemver(candidate) || !isSemver(c.semver) {
+		return false
+	}
+
+	cmp := compareSemver(candidate, c.semver)
+	switch c.operator {
+	case ">=":
+		return cmp >= 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case "==":
+		return cmp == 0
+	default:
+		return false
+	}
+}
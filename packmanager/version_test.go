@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: BSD-3-Clause
+// Copyright (c) 2022, Unikraft GmbH and The KraftKit Authors.
+// Licensed under the BSD-3-Clause License (the "License").
+// You may not use this file except in compliance with the License.
+package packmanager
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseVersionQuery(t *testing.T) {
+	tests := []struct {
+		expr       string
+		wantKind   VersionKind
+		wantSemver string
+		wantPrefix string
+		wantOp     string
+	}{
+		{expr: "latest", wantKind: VersionKindLatest},
+		{expr: "upgrade", wantKind: VersionKindUpgrade},
+		{expr: "patch", wantKind: VersionKindPatch},
+		{expr: "v1.2.3", wantKind: VersionKindExact, wantSemver: "v1.2.3"},
+		{expr: "v1", wantKind: VersionKindPrefix, wantPrefix: "v1"},
+		{expr: "v1.2", wantKind: VersionKindPrefix, wantPrefix: "v1.2"},
+		{expr: ">=1.2.3", wantKind: VersionKindRange, wantOp: ">=", wantSemver: "1.2.3"},
+		{expr: ">=1.2.3,<2.0.0", wantKind: VersionKindRange, wantOp: ">=", wantSemver: "1.2.3"},
+		{expr: "my-custom-branch", wantKind: VersionKindExact, wantSemver: "my-custom-branch"},
+		{expr: "", wantKind: VersionKindAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			vq := ParseVersionQuery(tt.expr)
+			if vq.Kind != tt.wantKind {
+				t.Fatalf("Kind = %v, want %v", vq.Kind, tt.wantKind)
+			}
+			if vq.Semver != tt.wantSemver {
+				t.Fatalf("Semver = %q, want %q", vq.Semver, tt.wantSemver)
+			}
+			if vq.Prefix != tt.wantPrefix {
+				t.Fatalf("Prefix = %q, want %q", vq.Prefix, tt.wantPrefix)
+			}
+			if vq.Operator != tt.wantOp {
+				t.Fatalf("Operator = %q, want %q", vq.Operator, tt.wantOp)
+			}
+		})
+	}
+}
+
+func TestVersionQueryMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		expr      string
+		candidate string
+		current   string
+		want      bool
+	}{
+		{name: "latest excludes prerelease", expr: "latest", candidate: "1.2.0-rc1", want: false},
+		{name: "latest allows release", expr: "latest", candidate: "1.2.0", want: true},
+		{name: "upgrade rejects older", expr: "upgrade", candidate: "1.0.0", current: "1.2.0", want: false},
+		{name: "upgrade allows newer", expr: "upgrade", candidate: "1.3.0", current: "1.2.0", want: true},
+		{name: "patch matches series", expr: "patch", candidate: "1.2.9", current: "1.2.0", want: true},
+		{name: "patch rejects other series", expr: "patch", candidate: "1.3.0", current: "1.2.0", want: false},
+		{name: "prefix vX matches major", expr: "v1", candidate: "1.9.9", want: true},
+		{name: "prefix vX.Y matches minor", expr: "v1.2", candidate: "1.2.9", want: true},
+		{name: "prefix vX.Y rejects other minor", expr: "v1.2", candidate: "1.3.0", want: false},
+		{name: "range intersection", expr: ">=1.2.3,<2.0.0", candidate: "1.5.0", want: true},
+		{name: "range intersection out of bounds", expr: ">=1.2.3,<2.0.0", candidate: "2.0.0", want: false},
+		{name: "range never matches a commit hash", expr: ">=1.2.3", candidate: "a1b2c3d", want: false},
+		{name: "exact matches verbatim", expr: "1.2.3", candidate: "1.2.3", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vq := ParseVersionQuery(tt.expr)
+			if got := vq.Match(tt.candidate, tt.current); got != tt.want {
+				t.Fatalf("Match(%q, %q) = %v, want %v", tt.candidate, tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVersionQuerySelectBestFallsBackToNewestCommit(t *testing.T) {
+	// Neither candidate is a tag, so there is no semver signal to order by;
+	// SelectBest must prefer the last one supplied (the manifest/commit
+	// listing order), not whichever sorts higher lexically.
+	candidates := []string{"zzzz000", "aaaa111"}
+
+	got := ParseVersionQuery("latest").SelectBest(candidates, "")
+	if want := "aaaa111"; got != want {
+		t.Fatalf("SelectBest() = %q, want %q (newest-supplied commit, not lexical max)", got, want)
+	}
+}
+
+func TestVersionQuerySelectBestPrefersTagsOverCommits(t *testing.T) {
+	candidates := []string{"deadbeef", "1.2.0"}
+
+	got := ParseVersionQuery("latest").SelectBest(candidates, "")
+	if want := "1.2.0"; got != want {
+		t.Fatalf("SelectBest() = %q, want %q", got, want)
+	}
+}
+
+func TestVersionQuerySelectBestPicksHighestSemver(t *testing.T) {
+	candidates := []string{"1.2.0", "1.10.0", "1.3.0"}
+
+	got := ParseVersionQuery("latest").SelectBest(candidates, "")
+	if want := "1.10.0"; got != want {
+		t.Fatalf("SelectBest() = %q, want %q", got, want)
+	}
+}
+
+// TestQueryResolveVersionWithNoVersionMatchesAnyCandidate reproduces the
+// overwhelmingly common case of a query with no WithVersion call at all
+// (e.g. `kraft pkg pull nginx`): it must resolve against whatever the
+// catalog has, not fail with ErrPackageNotFound against every candidate.
+func TestQueryResolveVersionWithNoVersionMatchesAnyCandidate(t *testing.T) {
+	q := NewQuery(WithName("nginx"))
+
+	got, err := q.ResolveVersion([]string{"1.0.0", "1.2.0", "2.0.0"}, "")
+	if err != nil {
+		t.Fatalf("ResolveVersion() error = %v, want a match", err)
+	}
+	if want := "2.0.0"; got != want {
+		t.Fatalf("ResolveVersion() = %q, want %q", got, want)
+	}
+}
+
+func TestVersionQueryMatchAnyIncludesPrereleases(t *testing.T) {
+	vq := ParseVersionQuery("")
+	if !vq.Match("1.0.0-rc1", "") {
+		t.Fatalf("Match() = false, want VersionKindAny to match prereleases too")
+	}
+}
+
+func TestQueryResolveVersionNotFound(t *testing.T) {
+	q := NewQuery(WithVersion(">=9.0.0"))
+
+	_, err := q.ResolveVersion([]string{"1.2.3"}, "")
+	if !errors.Is(err, ErrPackageNotFound) {
+		t.Fatalf("ResolveVersion() err = %v, want ErrPackageNotFound", err)
+	}
+}
+
+func TestCompareVersionsUnparseableIsNeutral(t *testing.T) {
+	if got := CompareVersions("abc123", "1.0.0"); got != 0 {
+		t.Fatalf("CompareVersions() = %d, want 0 for an unparseable operand", got)
+	}
+}